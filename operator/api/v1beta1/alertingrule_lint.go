@@ -0,0 +1,96 @@
+package v1beta1
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"github.com/grafana/loki/pkg/logql/syntax"
+)
+
+// labelMatcherPattern approximates the number of label matchers in a stream selector (e.g.
+// `{app="foo", env=~"prod|staging"}` has 2) without needing a full AST walk.
+var labelMatcherPattern = regexp.MustCompile(`[a-zA-Z_][a-zA-Z0-9_]*\s*(=~|!~|=|!=)\s*"`)
+
+// RuleLintResult is returned by LintAlertingRule/LintRecordingRule for a single rule, so CI
+// pipelines can validate rule changes against a running cluster's CRD schema without persisting
+// the object (e.g. via `kubectl create --dry-run=server` against a lint subresource).
+type RuleLintResult struct {
+	Rule string `json:"rule"`
+	AST  string `json:"ast,omitempty"`
+	// EstimatedMatchers is a rough stand-in for estimated series cardinality: the number of
+	// label matchers the expression's stream selector carries. More matchers generally means a
+	// narrower, cheaper query; it is not a substitute for running EXPLAIN against the actual
+	// index, which the lint path deliberately avoids since it must not touch object storage.
+	EstimatedMatchers int    `json:"estimatedMatchers"`
+	Error             string `json:"error,omitempty"`
+}
+
+// LintAlertingRule parses every rule in rule without persisting or reconciling it, returning one
+// RuleLintResult per rule.
+func LintAlertingRule(rule *AlertingRule) []RuleLintResult {
+	var results []RuleLintResult
+
+	for _, group := range rule.Spec.Groups {
+		for _, r := range group.Rules {
+			results = append(results, lintExpr(r.Alert, r.Expr))
+		}
+	}
+
+	return results
+}
+
+// LintRecordingRule parses every rule in rule without persisting or reconciling it, returning
+// one RuleLintResult per rule.
+func LintRecordingRule(rule *RecordingRule) []RuleLintResult {
+	var results []RuleLintResult
+
+	for _, group := range rule.Spec.Groups {
+		for _, r := range group.Rules {
+			results = append(results, lintExpr(r.Record, r.Expr))
+		}
+	}
+
+	return results
+}
+
+// LintAlertingRuleHandler is an http.HandlerFunc wrapper around LintAlertingRule, meant to be
+// registered as the CRD's dry-run lint subresource, e.g.
+// POST /apis/loki.grafana.com/v1beta1/namespaces/{ns}/alertingrules/{name}/lint. It decodes the
+// rule from the request body rather than reading it back from the API server, so a rule can be
+// linted before it's ever submitted.
+func LintAlertingRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var rule AlertingRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(LintAlertingRule(&rule))
+}
+
+// LintRecordingRuleHandler is the RecordingRule counterpart of LintAlertingRuleHandler.
+func LintRecordingRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var rule RecordingRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(LintRecordingRule(&rule))
+}
+
+func lintExpr(name, expr string) RuleLintResult {
+	parsed, err := syntax.ParseExpr(expr)
+	if err != nil {
+		return RuleLintResult{Rule: name, Error: err.Error()}
+	}
+
+	return RuleLintResult{
+		Rule:              name,
+		AST:               parsed.String(),
+		EstimatedMatchers: len(labelMatcherPattern.FindAllString(expr, -1)),
+	}
+}
@@ -0,0 +1,57 @@
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// alertingRuleWebhookLimits is injected once by SetupWebhookWithManager so the generated
+// ValidateCreate/ValidateUpdate methods (which the webhook.Validator interface requires to take
+// no extra arguments) have access to the ruler's tenant limits.
+var alertingRuleWebhookLimits RuleLimits
+
+// SetupWebhookWithManager registers the validating webhook for AlertingRule. limits is used to
+// check each rule group's for:/interval: fields and required labels against the owning tenant's
+// overrides.
+func (r *AlertingRule) SetupWebhookWithManager(mgr ctrl.Manager, limits RuleLimits) error {
+	alertingRuleWebhookLimits = limits
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// ValidateCreate implements webhook.Validator. It parses every rule group's expr with the LogQL
+// parser, and checks for:/interval: and required labels against the tenant's limits, so a
+// malformed AlertingRule is rejected before it ever reaches the ruler and crash-loops it.
+func (r *AlertingRule) ValidateCreate() error {
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator.
+func (r *AlertingRule) ValidateUpdate(runtime.Object) error {
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator. Deleting an AlertingRule never needs expression
+// validation.
+func (r *AlertingRule) ValidateDelete() error {
+	return nil
+}
+
+func (r *AlertingRule) validate() error {
+	errs := ValidateAlertingRule(r, alertingRuleWebhookLimits)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+
+	return fmt.Errorf("%s: %s", ReasonInvalidExpression, strings.Join(msgs, "; "))
+}
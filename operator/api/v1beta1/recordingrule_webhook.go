@@ -0,0 +1,53 @@
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// recordingRuleWebhookLimits mirrors alertingRuleWebhookLimits for RecordingRule.
+var recordingRuleWebhookLimits RuleLimits
+
+// SetupWebhookWithManager registers the validating webhook for RecordingRule. limits is used to
+// check each rule group's interval: field and required labels against the owning tenant's
+// overrides.
+func (r *RecordingRule) SetupWebhookWithManager(mgr ctrl.Manager, limits RuleLimits) error {
+	recordingRuleWebhookLimits = limits
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// ValidateCreate implements webhook.Validator.
+func (r *RecordingRule) ValidateCreate() error {
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator.
+func (r *RecordingRule) ValidateUpdate(runtime.Object) error {
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator. Deleting a RecordingRule never needs expression
+// validation.
+func (r *RecordingRule) ValidateDelete() error {
+	return nil
+}
+
+func (r *RecordingRule) validate() error {
+	errs := ValidateRecordingRule(r, recordingRuleWebhookLimits)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+
+	return fmt.Errorf("%s: %s", ReasonInvalidExpression, strings.Join(msgs, "; "))
+}
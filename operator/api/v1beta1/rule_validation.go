@@ -0,0 +1,145 @@
+package v1beta1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/loki/pkg/logql/syntax"
+)
+
+// ReasonInvalidExpression is written to an AlertingRule/RecordingRule's status.conditions when
+// one of its rule groups fails validation.
+const ReasonInvalidExpression = "InvalidExpression"
+
+// RuleLimits is the subset of the ruler's tenant limits a rule group is validated against. It
+// mirrors the per-tenant "for"/"interval" bounds operators configure via
+// per_tenant_override_config.
+type RuleLimits interface {
+	// TenantLimits returns the for:/interval: bounds for tenantID, or ok=false if the tenant
+	// has no override and the caller should fall back to its own defaults.
+	TenantLimits(tenantID string) (limits RuleTenantLimits, ok bool)
+}
+
+// RuleTenantLimits bounds the "for" and "interval" fields a tenant's alerting/recording rules
+// are allowed to use, and the labels they must carry.
+type RuleTenantLimits struct {
+	MinFor, MaxFor           time.Duration
+	MinInterval, MaxInterval time.Duration
+	RequiredLabels           map[string]string
+}
+
+// ruleValidationError describes a single rule within a group that failed validation.
+type ruleValidationError struct {
+	Group string
+	Rule  string
+	Err   error
+}
+
+func (e *ruleValidationError) Error() string {
+	return fmt.Sprintf("group %q, rule %q: %s", e.Group, e.Rule, e.Err)
+}
+
+func validateLogQLExpr(expr string) error {
+	if _, err := syntax.ParseExpr(expr); err != nil {
+		return fmt.Errorf("invalid LogQL expression: %w", err)
+	}
+	return nil
+}
+
+func validateForInterval(forDuration, interval time.Duration, limits RuleTenantLimits) error {
+	if limits.MinFor > 0 && forDuration < limits.MinFor {
+		return fmt.Errorf("for duration %s is below the tenant minimum of %s", forDuration, limits.MinFor)
+	}
+	if limits.MaxFor > 0 && forDuration > limits.MaxFor {
+		return fmt.Errorf("for duration %s exceeds the tenant maximum of %s", forDuration, limits.MaxFor)
+	}
+	return validateInterval(interval, limits)
+}
+
+// validateInterval checks only the interval: field against the tenant's bounds. RecordingRule
+// groups have no for: field, so they use this instead of validateForInterval to avoid having
+// the MinFor/MaxFor checks run against a meaningless zero duration.
+func validateInterval(interval time.Duration, limits RuleTenantLimits) error {
+	if limits.MinInterval > 0 && interval < limits.MinInterval {
+		return fmt.Errorf("interval %s is below the tenant minimum of %s", interval, limits.MinInterval)
+	}
+	if limits.MaxInterval > 0 && interval > limits.MaxInterval {
+		return fmt.Errorf("interval %s exceeds the tenant maximum of %s", interval, limits.MaxInterval)
+	}
+	return nil
+}
+
+func validateLabelMatchers(ruleLabels map[string]string, limits RuleTenantLimits) error {
+	for name, value := range limits.RequiredLabels {
+		if got, ok := ruleLabels[name]; !ok || got != value {
+			return fmt.Errorf("rule must carry label %s=%q required by tenant overrides", name, value)
+		}
+	}
+	return nil
+}
+
+func tenantLimitsOrDefault(tenantID string, limits RuleLimits) RuleTenantLimits {
+	if limits == nil {
+		return RuleTenantLimits{}
+	}
+	if tl, ok := limits.TenantLimits(tenantID); ok {
+		return tl
+	}
+	return RuleTenantLimits{}
+}
+
+// ValidateAlertingRule walks every group/rule in rule and returns one error per invalid rule.
+func ValidateAlertingRule(rule *AlertingRule, limits RuleLimits) []error {
+	var errs []error
+	tenantLimits := tenantLimitsOrDefault(rule.Spec.TenantID, limits)
+
+	for _, group := range rule.Spec.Groups {
+		interval := group.Interval.ToDuration()
+
+		for _, r := range group.Rules {
+			if err := validateLogQLExpr(r.Expr); err != nil {
+				errs = append(errs, &ruleValidationError{Group: group.Name, Rule: r.Alert, Err: err})
+				continue
+			}
+
+			if err := validateForInterval(r.For.ToDuration(), interval, tenantLimits); err != nil {
+				errs = append(errs, &ruleValidationError{Group: group.Name, Rule: r.Alert, Err: err})
+				continue
+			}
+
+			if err := validateLabelMatchers(r.Labels, tenantLimits); err != nil {
+				errs = append(errs, &ruleValidationError{Group: group.Name, Rule: r.Alert, Err: err})
+			}
+		}
+	}
+
+	return errs
+}
+
+// ValidateRecordingRule walks every group/rule in rule and returns one error per invalid rule.
+func ValidateRecordingRule(rule *RecordingRule, limits RuleLimits) []error {
+	var errs []error
+	tenantLimits := tenantLimitsOrDefault(rule.Spec.TenantID, limits)
+
+	for _, group := range rule.Spec.Groups {
+		interval := group.Interval.ToDuration()
+
+		for _, r := range group.Rules {
+			if err := validateLogQLExpr(r.Expr); err != nil {
+				errs = append(errs, &ruleValidationError{Group: group.Name, Rule: r.Record, Err: err})
+				continue
+			}
+
+			if err := validateInterval(interval, tenantLimits); err != nil {
+				errs = append(errs, &ruleValidationError{Group: group.Name, Rule: r.Record, Err: err})
+				continue
+			}
+
+			if err := validateLabelMatchers(r.Labels, tenantLimits); err != nil {
+				errs = append(errs, &ruleValidationError{Group: group.Name, Rule: r.Record, Err: err})
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,48 @@
+package v1beta1
+
+import (
+	"testing"
+	"time"
+)
+
+// TestValidateInterval_IgnoresForBounds guards against ValidateRecordingRule going back to
+// reusing validateForInterval(0, ...): RecordingRule groups have no for: field, so a zero
+// forDuration must never be checked against MinFor/MaxFor. validateInterval is the function
+// ValidateRecordingRule is expected to call instead.
+func TestValidateInterval_IgnoresForBounds(t *testing.T) {
+	limits := RuleTenantLimits{
+		MinFor:      time.Minute,
+		MaxFor:      time.Hour,
+		MinInterval: time.Minute,
+		MaxInterval: time.Hour,
+	}
+
+	if err := validateInterval(5*time.Minute, limits); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+}
+
+func TestValidateInterval_EnforcesBounds(t *testing.T) {
+	limits := RuleTenantLimits{MinInterval: time.Minute, MaxInterval: time.Hour}
+
+	if err := validateInterval(time.Second, limits); err == nil {
+		t.Fatal("expected an error for an interval below the tenant minimum")
+	}
+	if err := validateInterval(2*time.Hour, limits); err == nil {
+		t.Fatal("expected an error for an interval above the tenant maximum")
+	}
+	if err := validateInterval(5*time.Minute, limits); err != nil {
+		t.Fatalf("expected no error within bounds, got: %v", err)
+	}
+}
+
+func TestValidateForInterval_StillEnforcesForBounds(t *testing.T) {
+	limits := RuleTenantLimits{MinFor: time.Minute}
+
+	if err := validateForInterval(0, 5*time.Minute, limits); err == nil {
+		t.Fatal("expected an error: alerting rules must still enforce MinFor")
+	}
+	if err := validateForInterval(2*time.Minute, 5*time.Minute, limits); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+}
@@ -2,9 +2,12 @@ package controllers
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -22,6 +25,7 @@ type AlertingRuleReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+	Limits lokiv1beta1.RuleLimits
 }
 
 //+kubebuilder:rbac:groups=loki.grafana.com,resources=alertingrules,verbs=get;list;watch;create;update;patch;delete
@@ -37,7 +41,20 @@ type AlertingRuleReconciler struct {
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.11.0/pkg/reconcile
-func (r *AlertingRuleReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+func (r *AlertingRuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var rule lokiv1beta1.AlertingRule
+	if err := r.Get(ctx, req.NamespacedName, &rule); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if errs := lokiv1beta1.ValidateAlertingRule(&rule, r.Limits); len(errs) > 0 {
+		return ctrl.Result{}, r.reportInvalid(ctx, &rule, errs)
+	}
+
+	if err := r.clearInvalidCondition(ctx, &rule); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	err := lokistack.AnnotateForDiscoveredRules(ctx, r.Client)
 	if err != nil {
 		return ctrl.Result{
@@ -48,6 +65,42 @@ func (r *AlertingRuleReconciler) Reconcile(ctx context.Context, _ ctrl.Request)
 	return ctrl.Result{}, nil
 }
 
+// reportInvalid writes errs into rule's status.conditions with Reason=InvalidExpression instead
+// of letting the malformed expression reach the ruler. It does not requeue: there's nothing more
+// to do until the user fixes and re-submits the spec, which triggers a fresh reconcile.
+func (r *AlertingRuleReconciler) reportInvalid(ctx context.Context, rule *lokiv1beta1.AlertingRule, errs []error) error {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+
+	apimeta.SetStatusCondition(&rule.Status.Conditions, metav1.Condition{
+		Type:    "RuleValid",
+		Status:  metav1.ConditionFalse,
+		Reason:  lokiv1beta1.ReasonInvalidExpression,
+		Message: strings.Join(msgs, "; "),
+	})
+
+	return r.Status().Update(ctx, rule)
+}
+
+// clearInvalidCondition flips a previously-set RuleValid=False condition to RuleValid=True once
+// rule passes validation again.
+func (r *AlertingRuleReconciler) clearInvalidCondition(ctx context.Context, rule *lokiv1beta1.AlertingRule) error {
+	existing := apimeta.FindStatusCondition(rule.Status.Conditions, "RuleValid")
+	if existing == nil || existing.Status == metav1.ConditionTrue {
+		return nil
+	}
+
+	apimeta.SetStatusCondition(&rule.Status.Conditions, metav1.Condition{
+		Type:   "RuleValid",
+		Status: metav1.ConditionTrue,
+		Reason: "Valid",
+	})
+
+	return r.Status().Update(ctx, rule)
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *AlertingRuleReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
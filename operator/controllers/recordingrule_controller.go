@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/go-logr/logr"
+	lokiv1beta1 "github.com/grafana/loki/operator/api/v1beta1"
+	"github.com/grafana/loki/operator/controllers/internal/lokistack"
+)
+
+// RecordingRuleReconciler reconciles a RecordingRule object
+type RecordingRuleReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	Limits lokiv1beta1.RuleLimits
+}
+
+//+kubebuilder:rbac:groups=loki.grafana.com,resources=recordingrules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=loki.grafana.com,resources=recordingrules/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=loki.grafana.com,resources=recordingrules/finalizers,verbs=update
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+// TODO(user): Modify the Reconcile function to compare the state specified by
+// the RecordingRule object against the actual cluster state, and then
+// perform operations to make the cluster state reflect the state specified by
+// the user.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.11.0/pkg/reconcile
+func (r *RecordingRuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var rule lokiv1beta1.RecordingRule
+	if err := r.Get(ctx, req.NamespacedName, &rule); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if errs := lokiv1beta1.ValidateRecordingRule(&rule, r.Limits); len(errs) > 0 {
+		return ctrl.Result{}, r.reportInvalid(ctx, &rule, errs)
+	}
+
+	if err := r.clearInvalidCondition(ctx, &rule); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err := lokistack.AnnotateForDiscoveredRules(ctx, r.Client)
+	if err != nil {
+		return ctrl.Result{
+			Requeue:      true,
+			RequeueAfter: time.Second,
+		}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// reportInvalid writes errs into rule's status.conditions with Reason=InvalidExpression instead
+// of letting the malformed expression reach the ruler. It does not requeue: there's nothing more
+// to do until the user fixes and re-submits the spec, which triggers a fresh reconcile.
+func (r *RecordingRuleReconciler) reportInvalid(ctx context.Context, rule *lokiv1beta1.RecordingRule, errs []error) error {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+
+	apimeta.SetStatusCondition(&rule.Status.Conditions, metav1.Condition{
+		Type:    "RuleValid",
+		Status:  metav1.ConditionFalse,
+		Reason:  lokiv1beta1.ReasonInvalidExpression,
+		Message: strings.Join(msgs, "; "),
+	})
+
+	return r.Status().Update(ctx, rule)
+}
+
+// clearInvalidCondition flips a previously-set RuleValid=False condition to RuleValid=True once
+// rule passes validation again.
+func (r *RecordingRuleReconciler) clearInvalidCondition(ctx context.Context, rule *lokiv1beta1.RecordingRule) error {
+	existing := apimeta.FindStatusCondition(rule.Status.Conditions, "RuleValid")
+	if existing == nil || existing.Status == metav1.ConditionTrue {
+		return nil
+	}
+
+	apimeta.SetStatusCondition(&rule.Status.Conditions, metav1.Condition{
+		Type:   "RuleValid",
+		Status: metav1.ConditionTrue,
+		Reason: "Valid",
+	})
+
+	return r.Status().Update(ctx, rule)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RecordingRuleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&lokiv1beta1.RecordingRule{}).
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, &handler.EnqueueRequestForObject{}, builder.OnlyMetadata).
+		Complete(r)
+}
@@ -0,0 +1,79 @@
+package downloads
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/go-kit/log/level"
+
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+// quarantineDirName is the subdirectory of CacheDir that corrupt files are moved into instead
+// of being deleted outright, so operators can inspect them after a corruption event.
+const quarantineDirName = ".quarantine"
+
+// ChecksumIndexStorageClient is an optional extension of storage.Client. An indexStorageClient
+// that implements it can report the expected digest for a file it served, either read from
+// object metadata or from a sidecar `.sha256` file uploaded by the compactor. Clients that don't
+// implement it are treated as not supporting checksum verification, and VerifyChecksum becomes
+// a no-op against them.
+type ChecksumIndexStorageClient interface {
+	// GetFileChecksum returns the expected hex-encoded SHA-256 digest for fileName within
+	// tableName. ok is false if no digest is available for that file.
+	GetFileChecksum(ctx context.Context, tableName, fileName string) (digest string, ok bool, err error)
+}
+
+// verifyChecksum compares the SHA-256 digest of the file at path against the digest reported by
+// client for (tableName, fileName). It returns (true, nil) when the client doesn't have a digest
+// for this file, since there is nothing to verify against.
+func verifyChecksum(ctx context.Context, client ChecksumIndexStorageClient, tableName, fileName, path string) (bool, error) {
+	digest, ok, err := client.GetFileChecksum(ctx, tableName, fileName)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return true, nil
+	}
+
+	actual, err := fileChecksum(path)
+	if err != nil {
+		return false, err
+	}
+
+	return actual == digest, nil
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// quarantineFile moves a corrupt file out of the cache and into
+// <cacheDir>/.quarantine/<tableName>/<fileName> so it no longer gets served to a query but
+// remains available for operators to inspect. fileName may itself contain a "<userID>/..."
+// prefix for a per-tenant index file, in which case the matching subdirectory is created under
+// the quarantine directory too.
+func quarantineFile(cacheDir, tableName, fileName, path string) error {
+	dst := filepath.Join(cacheDir, quarantineDirName, tableName, fileName)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	level.Error(util_log.Logger).Log("msg", "quarantining corrupt index file", "table", tableName, "file", fileName, "dst", dst)
+	return os.Rename(path, dst)
+}
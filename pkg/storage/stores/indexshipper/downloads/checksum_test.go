@@ -0,0 +1,182 @@
+package downloads
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeChecksumClient struct {
+	digests map[string]string
+}
+
+func (f fakeChecksumClient) GetFileChecksum(_ context.Context, _, fileName string) (string, bool, error) {
+	digest, ok := f.digests[fileName]
+	return digest, ok, nil
+}
+
+func TestVerifyChecksum_DetectsMismatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.gz")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	client := fakeChecksumClient{digests: map[string]string{"index.gz": "not-the-real-digest"}}
+
+	ok, err := verifyChecksum(context.Background(), client, "table0", "index.gz", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Fatal("expected a digest mismatch to be reported as invalid")
+	}
+}
+
+func TestVerifyChecksum_PassesOnMatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.gz")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	digest, err := fileChecksum(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := fakeChecksumClient{digests: map[string]string{"index.gz": digest}}
+
+	ok, err := verifyChecksum(context.Background(), client, "table0", "index.gz", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected a matching digest to be reported as valid")
+	}
+}
+
+func TestVerifyChecksum_NoDigestIsTreatedAsValid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.gz")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	client := fakeChecksumClient{digests: map[string]string{}}
+
+	ok, err := verifyChecksum(context.Background(), client, "table0", "index.gz", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected no-digest-available to be treated as nothing to verify")
+	}
+}
+
+func TestQuarantineFile_MovesFileOutOfCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	tableDir := filepath.Join(cacheDir, "table0")
+	if err := os.MkdirAll(tableDir, 0o755); err != nil {
+		t.Fatalf("failed to create table dir: %v", err)
+	}
+
+	path := filepath.Join(tableDir, "index.gz")
+	if err := os.WriteFile(path, []byte("corrupt"), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	if err := quarantineFile(cacheDir, "table0", "index.gz", path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected the original file to be gone, got err: %v", err)
+	}
+
+	quarantined := filepath.Join(cacheDir, quarantineDirName, "table0", "index.gz")
+	if _, err := os.Stat(quarantined); err != nil {
+		t.Fatalf("expected the file to be present in quarantine: %v", err)
+	}
+}
+
+// TestQuarantineFile_NestedUserFile guards against quarantineFile failing to move a per-user
+// index file (checksumKey "<userID>/<fileName>") because the matching subdirectory wasn't
+// created under the quarantine directory first.
+func TestQuarantineFile_NestedUserFile(t *testing.T) {
+	cacheDir := t.TempDir()
+	userDir := filepath.Join(cacheDir, "table0", "user1")
+	if err := os.MkdirAll(userDir, 0o755); err != nil {
+		t.Fatalf("failed to create user dir: %v", err)
+	}
+
+	path := filepath.Join(userDir, "index.gz")
+	if err := os.WriteFile(path, []byte("corrupt"), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	if err := quarantineFile(cacheDir, "table0", "user1/index.gz", path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	quarantined := filepath.Join(cacheDir, quarantineDirName, "table0", "user1", "index.gz")
+	if _, err := os.Stat(quarantined); err != nil {
+		t.Fatalf("expected the file to be present in quarantine: %v", err)
+	}
+}
+
+// TestVerifyLocalTableFiles_ChecksumsPerUserFiles guards against the walk in
+// verifyLocalTableFiles skipping per-user index directories (tablePath/<userID>/...), which is
+// where the vast majority of index files in a real multi-tenant deployment live. A flat file
+// directly under tablePath and a per-user file one level down must both be checksummed and, on
+// mismatch, quarantined.
+func TestVerifyLocalTableFiles_ChecksumsPerUserFiles(t *testing.T) {
+	cacheDir := t.TempDir()
+	tablePath := filepath.Join(cacheDir, "table0")
+
+	if err := os.MkdirAll(filepath.Join(tablePath, "user1"), 0o755); err != nil {
+		t.Fatalf("failed to create user dir: %v", err)
+	}
+
+	commonPath := filepath.Join(tablePath, "common.gz")
+	if err := os.WriteFile(commonPath, []byte("common"), 0o644); err != nil {
+		t.Fatalf("failed to write common file: %v", err)
+	}
+
+	userPath := filepath.Join(tablePath, "user1", "index.gz")
+	if err := os.WriteFile(userPath, []byte("per-user"), 0o644); err != nil {
+		t.Fatalf("failed to write per-user file: %v", err)
+	}
+
+	// both files get a digest that doesn't match their actual contents, so both must be
+	// quarantined if (and only if) the walk actually reaches them.
+	client := fakeChecksumClient{digests: map[string]string{
+		"common.gz":      "not-the-real-digest",
+		"user1/index.gz": "not-the-real-digest",
+	}}
+
+	tm := &tableManager{
+		cfg:     Config{CacheDir: cacheDir},
+		metrics: newMetrics(nil),
+		ctx:     context.Background(),
+	}
+
+	if err := tm.verifyLocalTableFiles("table0", tablePath, client); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(commonPath); !os.IsNotExist(err) {
+		t.Fatalf("expected the common file to have been quarantined, got err: %v", err)
+	}
+	if _, err := os.Stat(userPath); !os.IsNotExist(err) {
+		t.Fatalf("expected the per-user file to have been quarantined, got err: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(cacheDir, quarantineDirName, "table0", "common.gz")); err != nil {
+		t.Fatalf("expected the common file to be present in quarantine: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, quarantineDirName, "table0", "user1", "index.gz")); err != nil {
+		t.Fatalf("expected the per-user file to be present in quarantine: %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package downloads
+
+import (
+	"sync"
+	"time"
+)
+
+// lease tracks how long a single user's index within a table should be kept cached after its
+// last access, before cleanupCache is allowed to drop it.
+type lease struct {
+	duration   time.Duration
+	lastAccess time.Time
+	expiresAt  time.Time
+}
+
+// leaseTracker holds a per-table, per-user lease, renewed on every ForEach/EnsureQueryReadiness
+// call. Lease duration scales with access frequency: a user accessed again before their lease
+// expires gets a longer lease next time (up to MaxLease); one accessed only after expiring gets
+// a shorter one (down to MinLease). This avoids the "download -> expire -> re-download" churn a
+// fixed CacheTTL causes for tenants whose query cadence is slightly longer than the TTL.
+type leaseTracker struct {
+	minLease, maxLease time.Duration
+	decayFactor        float64
+	metrics            *metrics
+
+	mtx      sync.Mutex
+	perTable map[string]map[string]*lease
+
+	// lastTouched tracks tables that have no per-user lease at all - e.g. a legacy table made up
+	// only of a shared/common index file, never downloaded or queried per-tenant - so
+	// cleanupCache still has something to sweep them against. Renew never populates this; only
+	// Touch does.
+	lastTouched map[string]time.Time
+}
+
+func newLeaseTracker(minLease, maxLease time.Duration, decayFactor float64, m *metrics) *leaseTracker {
+	return &leaseTracker{
+		minLease:    minLease,
+		maxLease:    maxLease,
+		decayFactor: decayFactor,
+		metrics:     m,
+		perTable:    make(map[string]map[string]*lease),
+		lastTouched: make(map[string]time.Time),
+	}
+}
+
+// Touch records that tableName was loaded, synced, or downloaded as of now, regardless of
+// whether any per-user lease exists for it. It is the fallback cleanupCache uses for tables that
+// never have a per-user lease tracked against them.
+func (lt *leaseTracker) Touch(tableName string, now time.Time) {
+	lt.mtx.Lock()
+	defer lt.mtx.Unlock()
+
+	lt.lastTouched[tableName] = now
+}
+
+// HasUsers reports whether tableName currently has any per-user lease tracked against it.
+func (lt *leaseTracker) HasUsers(tableName string) bool {
+	lt.mtx.Lock()
+	defer lt.mtx.Unlock()
+
+	return len(lt.perTable[tableName]) > 0
+}
+
+// TouchedTableExpired reports whether tableName was last Touch-ed more than ttl ago, and stops
+// tracking it if so (the caller is expected to drop the table once this returns true).
+func (lt *leaseTracker) TouchedTableExpired(tableName string, ttl time.Duration, now time.Time) bool {
+	lt.mtx.Lock()
+	defer lt.mtx.Unlock()
+
+	last, ok := lt.lastTouched[tableName]
+	if !ok || ttl <= 0 {
+		return false
+	}
+
+	if now.Sub(last) <= ttl {
+		return false
+	}
+
+	delete(lt.lastTouched, tableName)
+	return true
+}
+
+// Renew records an access to tableName by userID and returns the lease duration granted.
+func (lt *leaseTracker) Renew(tableName, userID string) time.Duration {
+	lt.mtx.Lock()
+	defer lt.mtx.Unlock()
+
+	users, ok := lt.perTable[tableName]
+	if !ok {
+		users = make(map[string]*lease)
+		lt.perTable[tableName] = users
+	}
+
+	now := time.Now()
+	l, ok := users[userID]
+	if !ok {
+		l = &lease{duration: lt.minLease}
+	} else if now.Before(l.expiresAt) {
+		// accessed again while still hot: grow the lease.
+		l.duration = time.Duration(float64(l.duration) / lt.decayFactor)
+		if l.duration > lt.maxLease {
+			l.duration = lt.maxLease
+		}
+	} else {
+		// accessed only after going cold: shrink the lease back down.
+		l.duration = time.Duration(float64(l.duration) * lt.decayFactor)
+		if l.duration < lt.minLease {
+			l.duration = lt.minLease
+		}
+	}
+
+	l.lastAccess = now
+	l.expiresAt = now.Add(l.duration)
+	users[userID] = l
+
+	if lt.metrics != nil {
+		lt.metrics.tableLeaseDurationSeconds.WithLabelValues(tableName).Set(l.duration.Seconds())
+		lt.metrics.tableLeaseRenewalsTotal.WithLabelValues(tableName).Inc()
+	}
+
+	return l.duration
+}
+
+// ExpiredUsers returns, and stops tracking, the users in tableName whose lease has expired as of
+// now.
+func (lt *leaseTracker) ExpiredUsers(tableName string, now time.Time) []string {
+	lt.mtx.Lock()
+	defer lt.mtx.Unlock()
+
+	users, ok := lt.perTable[tableName]
+	if !ok {
+		return nil
+	}
+
+	var expired []string
+	for userID, l := range users {
+		if now.After(l.expiresAt) {
+			expired = append(expired, userID)
+			delete(users, userID)
+		}
+	}
+
+	if len(users) == 0 {
+		delete(lt.perTable, tableName)
+	}
+
+	return expired
+}
+
+// IsEmpty reports whether tableName has no users with an active lease left, meaning the whole
+// table can be dropped rather than just individual per-user index files.
+func (lt *leaseTracker) IsEmpty(tableName string) bool {
+	lt.mtx.Lock()
+	defer lt.mtx.Unlock()
+
+	return len(lt.perTable[tableName]) == 0
+}
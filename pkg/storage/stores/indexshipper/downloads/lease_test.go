@@ -0,0 +1,105 @@
+package downloads
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeaseTracker_GrowsOnHotRenewal(t *testing.T) {
+	lt := newLeaseTracker(time.Minute, time.Hour, 0.5, nil)
+
+	first := lt.Renew("table1", "user1")
+	if first != time.Minute {
+		t.Fatalf("expected first renewal to grant MinLease (%s), got %s", time.Minute, first)
+	}
+
+	second := lt.Renew("table1", "user1")
+	if second <= first {
+		t.Fatalf("expected a renewal while still within lease to grow the duration, got %s (was %s)", second, first)
+	}
+}
+
+func TestLeaseTracker_GrowthIsCappedAtMaxLease(t *testing.T) {
+	lt := newLeaseTracker(time.Minute, 2*time.Minute, 0.5, nil)
+
+	var last time.Duration
+	for i := 0; i < 10; i++ {
+		last = lt.Renew("table1", "user1")
+	}
+
+	if last > 2*time.Minute {
+		t.Fatalf("expected lease growth to be capped at MaxLease (%s), got %s", 2*time.Minute, last)
+	}
+}
+
+func TestLeaseTracker_ShrinksAfterGoingCold(t *testing.T) {
+	lt := newLeaseTracker(time.Minute, time.Hour, 0.5, nil)
+
+	// a hot renewal first grows the lease above MinLease, so a cold one has room to shrink it.
+	lt.Renew("table1", "user1")
+	first := lt.Renew("table1", "user1")
+
+	// force the lease to have expired.
+	lt.mtx.Lock()
+	lt.perTable["table1"]["user1"].expiresAt = time.Now().Add(-time.Second)
+	lt.mtx.Unlock()
+
+	second := lt.Renew("table1", "user1")
+	if second >= first {
+		t.Fatalf("expected a renewal after expiring to shrink the duration, got %s (was %s)", second, first)
+	}
+}
+
+func TestLeaseTracker_ExpiredUsersStopsTrackingThem(t *testing.T) {
+	lt := newLeaseTracker(time.Millisecond, time.Hour, 0.5, nil)
+	lt.Renew("table1", "user1")
+
+	expired := lt.ExpiredUsers("table1", time.Now().Add(time.Second))
+	if len(expired) != 1 || expired[0] != "user1" {
+		t.Fatalf("expected user1 to have expired, got %v", expired)
+	}
+
+	if !lt.IsEmpty("table1") {
+		t.Fatal("expected table1 to be empty after its only user expired")
+	}
+
+	if expired := lt.ExpiredUsers("table1", time.Now()); len(expired) != 0 {
+		t.Fatalf("expected no further expired users once already swept, got %v", expired)
+	}
+}
+
+func TestLeaseTracker_HasUsersReflectsPerUserLeases(t *testing.T) {
+	lt := newLeaseTracker(time.Minute, time.Hour, 0.5, nil)
+
+	if lt.HasUsers("table1") {
+		t.Fatal("expected a table with no renewals to report no users")
+	}
+
+	lt.Renew("table1", "user1")
+	if !lt.HasUsers("table1") {
+		t.Fatal("expected a table with a renewed user to report having users")
+	}
+}
+
+func TestLeaseTracker_TouchedTableExpired(t *testing.T) {
+	lt := newLeaseTracker(time.Minute, time.Hour, 0.5, nil)
+
+	if lt.TouchedTableExpired("table1", time.Minute, time.Now()) {
+		t.Fatal("expected a table that was never touched to never be reported as expired")
+	}
+
+	now := time.Now()
+	lt.Touch("table1", now)
+
+	if lt.TouchedTableExpired("table1", time.Minute, now.Add(30*time.Second)) {
+		t.Fatal("expected a table touched well within ttl to not be expired")
+	}
+
+	if !lt.TouchedTableExpired("table1", time.Minute, now.Add(2*time.Minute)) {
+		t.Fatal("expected a table touched longer than ttl ago to be expired")
+	}
+
+	if lt.TouchedTableExpired("table1", time.Minute, now.Add(3*time.Minute)) {
+		t.Fatal("expected a table to stop being tracked once reported expired once")
+	}
+}
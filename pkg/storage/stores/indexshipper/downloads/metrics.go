@@ -0,0 +1,101 @@
+package downloads
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	statusSuccess = "success"
+	statusFailure = "failure"
+)
+
+type metrics struct {
+	tablesSyncOperationTotal               *prometheus.CounterVec
+	tablesDownloadOperationDurationSeconds prometheus.Gauge
+
+	// stats for the query readiness download pool as a whole; they carry no per-worker label.
+	downloadPoolInFlight       prometheus.Gauge
+	downloadPoolQueueLength    prometheus.Gauge
+	downloadPoolBytesPerSecond prometheus.Gauge
+
+	tablesCorruptionTotal *prometheus.CounterVec
+
+	// shardOwnershipTotal counts how many tenant×table pairs this instance claimed as its
+	// shard during query readiness. crossShardLazyFetchesTotal counts ForEach calls that
+	// landed on this instance for a tenant×table pair it does not own a shard of.
+	shardOwnershipTotal        prometheus.Counter
+	crossShardLazyFetchesTotal prometheus.Counter
+
+	tableLeaseDurationSeconds *prometheus.GaugeVec
+	tableLeaseRenewalsTotal   *prometheus.CounterVec
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	m := &metrics{
+		tablesSyncOperationTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "tables_sync_operation_total",
+			Help:      "Total number of table sync operations done by status",
+		}, []string{"status"}),
+		tablesDownloadOperationDurationSeconds: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "tables_download_operation_duration_seconds",
+			Help:      "Time (in seconds) spent in downloading updated tables",
+		}),
+		downloadPoolInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "download_pool_inflight_jobs",
+			Help:      "Number of query readiness download jobs currently being processed by the worker pool",
+		}),
+		downloadPoolQueueLength: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "download_pool_queue_length",
+			Help:      "Number of query readiness download jobs waiting for a free worker",
+		}),
+		downloadPoolBytesPerSecond: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "download_pool_bytes_per_second",
+			Help:      "Estimated bytes per second of the most recently completed query readiness download job, from its estimated size and time since it was queued",
+		}),
+		tablesCorruptionTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "tables_corruption_total",
+			Help:      "Total number of index files found corrupt (checksum mismatch) by reason",
+		}, []string{"reason"}),
+		shardOwnershipTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "shard_ownership_total",
+			Help:      "Total number of tenant-table shards this instance claimed ownership of during query readiness",
+		}),
+		crossShardLazyFetchesTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "cross_shard_lazy_fetches_total",
+			Help:      "Total number of ForEach calls served for a tenant-table shard this instance does not own",
+		}),
+		tableLeaseDurationSeconds: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "table_lease_duration_seconds",
+			Help:      "Most recently granted per-user cache lease duration for a table",
+		}, []string{"table"}),
+		tableLeaseRenewalsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "table_lease_renewals_total",
+			Help:      "Total number of per-user cache lease renewals for a table",
+		}, []string{"table"}),
+	}
+
+	if r != nil {
+		r.MustRegister(
+			m.tablesSyncOperationTotal,
+			m.tablesDownloadOperationDurationSeconds,
+			m.downloadPoolInFlight,
+			m.downloadPoolQueueLength,
+			m.downloadPoolBytesPerSecond,
+			m.tablesCorruptionTotal,
+			m.shardOwnershipTotal,
+			m.crossShardLazyFetchesTotal,
+			m.tableLeaseDurationSeconds,
+			m.tableLeaseRenewalsTotal,
+		)
+	}
+
+	return m
+}
@@ -0,0 +1,195 @@
+package downloads
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+)
+
+// downloadJob is a single unit of query readiness pre-download work, scoped to one table
+// (and, where applicable, one user within that table). run carries its own cancellable
+// context via closure, so the pool's internal worker context only governs worker lifetime,
+// not job cancellation.
+type downloadJob struct {
+	tableName string
+	run       func() error
+	result    chan<- error
+
+	// estimatedBytes is the approximate number of bytes run is expected to read from object
+	// storage, used to scale how many tokens Dispatch takes from the rate limiter before
+	// admitting the job to a worker. 0 disables rate limiting for this job.
+	estimatedBytes int64
+}
+
+// downloadPool runs query readiness downloadJobs on a fixed, resizable number of goroutines,
+// optionally throttling aggregate bytes read from object storage via a global token bucket.
+//
+// Dispatch fans jobs out through an errgroup so that the first job to fail cancels the errgroup's
+// context (derived from the caller's) and abandons every job still queued behind it, preserving the "return on first error"
+// semantics that ensureQueryReadiness relied on when it ran serially.
+type downloadPool struct {
+	jobs    chan downloadJob
+	limiter *rate.Limiter
+	metrics *metrics
+
+	mtx    sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// newDownloadPool builds a pool with n workers. rateLimitMBps <= 0 disables rate limiting.
+func newDownloadPool(n, rateLimitMBps int, m *metrics) *downloadPool {
+	p := &downloadPool{
+		jobs:    make(chan downloadJob),
+		metrics: m,
+	}
+
+	if rateLimitMBps > 0 {
+		burst := rateLimitMBps << 20
+		p.limiter = rate.NewLimiter(rate.Limit(burst), burst)
+	}
+
+	p.Resize(n)
+	return p
+}
+
+// Resize changes the number of workers consuming jobs from the pool. It can be called at any
+// point in the pool's lifetime, e.g. to scale workers up during initial startup/catch-up and
+// back down once steady state is reached. In-flight jobs are left to finish; only the workers
+// themselves are torn down and recreated.
+func (p *downloadPool) Resize(n int) {
+	if n <= 0 {
+		n = 1
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.cancel != nil {
+		p.cancel()
+		p.wg.Wait()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer p.wg.Done()
+			p.workerLoop(ctx)
+		}()
+	}
+}
+
+func (p *downloadPool) workerLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-p.jobs:
+			if p.metrics != nil {
+				p.metrics.downloadPoolInFlight.Inc()
+			}
+			job.result <- job.run()
+			if p.metrics != nil {
+				p.metrics.downloadPoolInFlight.Dec()
+			}
+		}
+	}
+}
+
+// Dispatch submits jobs to the pool and waits for all of them to either finish or for the first
+// one to fail, at which point ctx is cancelled and jobs not yet picked up by a worker are
+// abandoned.
+func (p *downloadPool) Dispatch(ctx context.Context, jobs []downloadJob) error {
+	g, ctx := errgroup.WithContext(ctx)
+
+	for _, job := range jobs {
+		job := job
+		result := make(chan error, 1)
+		job.result = result
+
+		g.Go(func() error {
+			if p.limiter != nil && job.estimatedBytes > 0 {
+				if err := waitForBytes(ctx, p.limiter, job.estimatedBytes); err != nil {
+					return err
+				}
+			}
+
+			if p.metrics != nil {
+				p.metrics.downloadPoolQueueLength.Inc()
+			}
+
+			start := time.Now()
+
+			var sendErr error
+			select {
+			case p.jobs <- job:
+			case <-ctx.Done():
+				sendErr = ctx.Err()
+			}
+
+			if p.metrics != nil {
+				p.metrics.downloadPoolQueueLength.Dec()
+			}
+			if sendErr != nil {
+				return sendErr
+			}
+
+			select {
+			case err := <-result:
+				if err == nil && p.metrics != nil && job.estimatedBytes > 0 {
+					if elapsed := time.Since(start).Seconds(); elapsed > 0 {
+						p.metrics.downloadPoolBytesPerSecond.Set(float64(job.estimatedBytes) / elapsed)
+					}
+				}
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}
+
+	return g.Wait()
+}
+
+// waitForBytes drains n tokens from limiter, one burst-sized chunk at a time, so a job whose
+// estimated size exceeds the limiter's burst (one second's worth of the configured rate) still
+// throttles correctly instead of failing with "burst exceeds limiter's burst" from a single
+// oversized WaitN call.
+func waitForBytes(ctx context.Context, limiter *rate.Limiter, n int64) error {
+	burst := int64(limiter.Burst())
+	if burst <= 0 {
+		return nil
+	}
+
+	for n > 0 {
+		take := n
+		if take > burst {
+			take = burst
+		}
+
+		if err := limiter.WaitN(ctx, int(take)); err != nil {
+			return err
+		}
+
+		n -= take
+	}
+
+	return nil
+}
+
+// Stop tears down all workers. It is safe to call Stop without a prior Resize call.
+func (p *downloadPool) Stop() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.cancel != nil {
+		p.cancel()
+		p.wg.Wait()
+	}
+}
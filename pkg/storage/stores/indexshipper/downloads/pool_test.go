@@ -0,0 +1,42 @@
+package downloads
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestWaitForBytes_ConsumesTokensProportionalToSize guards against the rate limiter only ever
+// consuming a single token per job regardless of size: a job estimated at several multiples of
+// the limiter's burst must take proportionally longer to admit than a job within a single burst.
+func TestWaitForBytes_ConsumesTokensProportionalToSize(t *testing.T) {
+	const burst = 1 << 10 // 1KiB/s
+	limiter := rate.NewLimiter(rate.Limit(burst), burst)
+
+	start := time.Now()
+	if err := waitForBytes(context.Background(), limiter, burst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	withinBurst := time.Since(start)
+
+	// a fresh limiter, now asked to admit 3x its burst: this must take meaningfully longer since
+	// it has to wait for the bucket to refill twice more.
+	limiter = rate.NewLimiter(rate.Limit(burst), burst)
+	start = time.Now()
+	if err := waitForBytes(context.Background(), limiter, 3*burst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	overBurst := time.Since(start)
+
+	if overBurst <= withinBurst {
+		t.Fatalf("expected draining 3x the burst to take longer than draining exactly the burst; within=%s over=%s", withinBurst, overBurst)
+	}
+}
+
+func TestWaitForBytes_NoLimiterIsNoop(t *testing.T) {
+	if err := waitForBytes(context.Background(), rate.NewLimiter(rate.Inf, 0), 1<<20); err != nil {
+		t.Fatalf("unexpected error with an unlimited limiter: %v", err)
+	}
+}
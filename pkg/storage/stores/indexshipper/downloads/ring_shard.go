@@ -0,0 +1,34 @@
+package downloads
+
+import "hash/fnv"
+
+// NewRingShardTable builds an IndexGatewayShardTable that consistently hashes each tenant×table
+// pair onto exactly one of a tenant's replicationFactor replicas, identified here by replicaIndex
+// (this instance's position, 0-based, among the tenant's replica set as reported by the ring).
+// This is what lets a group of IndexGateway replicas in ring mode split up query readiness
+// pre-downloads for the tables a tenant owns instead of every replica redundantly downloading
+// all of them; a replica that isn't assigned a given table still serves it lazily via
+// getOrCreateTable/ForEach.
+//
+// replicaIndex and replicationFactor are expected to come from the ring at the call site (e.g.
+// re-derived on every ring topology change), not from this package, which has no ring dependency
+// of its own.
+func NewRingShardTable(replicaIndex, replicationFactor int) IndexGatewayShardTable {
+	return func(tenant, tableName string) bool {
+		if replicationFactor <= 0 {
+			return true
+		}
+
+		return shardIndex(tenant, tableName, replicationFactor) == replicaIndex%replicationFactor
+	}
+}
+
+// shardIndex deterministically maps a tenant×table pair onto one of n shards.
+func shardIndex(tenant, tableName string, n int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(tenant))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(tableName))
+
+	return int(h.Sum32() % uint32(n))
+}
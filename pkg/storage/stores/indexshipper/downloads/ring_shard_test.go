@@ -0,0 +1,43 @@
+package downloads
+
+import "testing"
+
+func TestNewRingShardTable_ExactlyOneReplicaOwnsEachPair(t *testing.T) {
+	const replicationFactor = 3
+
+	shards := make([]IndexGatewayShardTable, replicationFactor)
+	for i := range shards {
+		shards[i] = NewRingShardTable(i, replicationFactor)
+	}
+
+	for _, tenant := range []string{"tenant-a", "tenant-b", "tenant-c"} {
+		for _, table := range []string{"index_19000", "index_19001", "index_19002"} {
+			owners := 0
+			for _, shard := range shards {
+				if shard(tenant, table) {
+					owners++
+				}
+			}
+			if owners != 1 {
+				t.Fatalf("expected exactly one replica to own (%s, %s), got %d", tenant, table, owners)
+			}
+		}
+	}
+}
+
+func TestNewRingShardTable_IsDeterministic(t *testing.T) {
+	shard := NewRingShardTable(1, 3)
+	first := shard("tenant-a", "index_19000")
+	for i := 0; i < 10; i++ {
+		if shard("tenant-a", "index_19000") != first {
+			t.Fatal("expected repeated calls for the same tenant/table to return the same result")
+		}
+	}
+}
+
+func TestNewRingShardTable_ZeroReplicationFactorOwnsEverything(t *testing.T) {
+	shard := NewRingShardTable(0, 0)
+	if !shard("tenant-a", "index_19000") {
+		t.Fatal("expected a replication factor of 0 to fall back to owning everything")
+	}
+}
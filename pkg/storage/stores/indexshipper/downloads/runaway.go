@@ -0,0 +1,186 @@
+package downloads
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// recentRunawaysCapacity bounds the in-memory ring buffer of recent runaway ForEach calls
+// exposed via RunawayManager.RecentRunawaysHandler.
+const recentRunawaysCapacity = 100
+
+// RunawayRecord describes a single ForEach call that tripped one of the runaway thresholds.
+type RunawayRecord struct {
+	Tenant   string        `json:"tenant"`
+	Table    string        `json:"table"`
+	Reason   string        `json:"reason"`
+	Duration time.Duration `json:"duration"`
+	Bytes    int64         `json:"bytes"`
+	Chunks   int64         `json:"chunks"`
+	At       time.Time     `json:"at"`
+}
+
+// RunawayManager watches every TableManager.ForEach call against per-tenant thresholds
+// (MaxForEachDuration, MaxForEachBytes, MaxForEachChunks from the Limits interface) and, when a
+// threshold is exceeded, cancels the call's context, records it for operators to inspect, and
+// puts the tenant into a cooldown so further calls fail fast instead of repeating the same
+// pathological query.
+type RunawayManager struct {
+	limits Limits
+
+	mtx           sync.Mutex
+	cooldownUntil map[string]time.Time
+	recent        []RunawayRecord
+	recentIdx     int
+}
+
+// NewRunawayManager builds a RunawayManager that reads thresholds from limits.
+func NewRunawayManager(limits Limits) *RunawayManager {
+	return &RunawayManager{
+		limits:        limits,
+		cooldownUntil: make(map[string]time.Time),
+	}
+}
+
+// runawayCall tracks a single in-flight ForEach call.
+type runawayCall struct {
+	tenant, table string
+	start         time.Time
+	bytes         int64
+	chunks        int64
+	cancel        context.CancelFunc
+	timer         *time.Timer
+}
+
+// AddBytes records bytes read from the cache/backing store for this call, cancelling it if
+// MaxForEachBytes is exceeded.
+func (c *runawayCall) AddBytes(rm *RunawayManager, n int64) {
+	total := atomic.AddInt64(&c.bytes, n)
+	if max := rm.maxBytes(c.tenant); max > 0 && total > max {
+		rm.trip(c, "max_bytes_exceeded")
+	}
+}
+
+// AddChunk records a single index chunk visited by this call, cancelling it if MaxForEachChunks
+// is exceeded.
+func (c *runawayCall) AddChunk(rm *RunawayManager) {
+	total := atomic.AddInt64(&c.chunks, 1)
+	if max := rm.maxChunks(c.tenant); max > 0 && total > max {
+		rm.trip(c, "max_chunks_exceeded")
+	}
+}
+
+func (rm *RunawayManager) maxDuration(tenant string) time.Duration {
+	if limits, ok := rm.limits.AllByUserID()[tenant]; ok && limits.MaxForEachDuration > 0 {
+		return limits.MaxForEachDuration
+	}
+	return rm.limits.DefaultLimits().MaxForEachDuration
+}
+
+func (rm *RunawayManager) maxBytes(tenant string) int64 {
+	if limits, ok := rm.limits.AllByUserID()[tenant]; ok && limits.MaxForEachBytes > 0 {
+		return limits.MaxForEachBytes
+	}
+	return rm.limits.DefaultLimits().MaxForEachBytes
+}
+
+func (rm *RunawayManager) maxChunks(tenant string) int64 {
+	if limits, ok := rm.limits.AllByUserID()[tenant]; ok && limits.MaxForEachChunks > 0 {
+		return limits.MaxForEachChunks
+	}
+	return rm.limits.DefaultLimits().MaxForEachChunks
+}
+
+// cooldownFor is how long a tenant is put into cooldown after tripping a runaway threshold.
+// Subsequent ForEach calls for that tenant fail fast for the duration instead of being allowed
+// to repeat the same pathological query.
+const cooldownFor = 30 * time.Second
+
+// Register begins tracking a ForEach call for tenant against tableName. It returns a derived,
+// cancellable context (cancelled if a threshold trips mid-call) and an error if the tenant is
+// currently in cooldown from a previous runaway.
+func (rm *RunawayManager) Register(ctx context.Context, tenant, tableName string) (context.Context, *runawayCall, error) {
+	rm.mtx.Lock()
+	until, inCooldown := rm.cooldownUntil[tenant]
+	rm.mtx.Unlock()
+
+	if inCooldown {
+		if time.Now().Before(until) {
+			return nil, nil, fmt.Errorf("tenant %s is in runaway cooldown until %s", tenant, until.Format(time.RFC3339))
+		}
+		rm.mtx.Lock()
+		delete(rm.cooldownUntil, tenant)
+		rm.mtx.Unlock()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	call := &runawayCall{
+		tenant: tenant,
+		table:  tableName,
+		start:  time.Now(),
+		cancel: cancel,
+	}
+
+	if maxDuration := rm.maxDuration(tenant); maxDuration > 0 {
+		call.timer = time.AfterFunc(maxDuration, func() {
+			rm.trip(call, "max_duration_exceeded")
+		})
+	}
+
+	return ctx, call, nil
+}
+
+// Done stops tracking call. It must be called once the ForEach call it was registered for
+// returns.
+func (rm *RunawayManager) Done(call *runawayCall) {
+	if call.timer != nil {
+		call.timer.Stop()
+	}
+	call.cancel()
+}
+
+// trip cancels call's context, records it in the recent-runaways ring buffer, and puts its
+// tenant into cooldown.
+func (rm *RunawayManager) trip(call *runawayCall, reason string) {
+	call.cancel()
+
+	record := RunawayRecord{
+		Tenant:   call.tenant,
+		Table:    call.table,
+		Reason:   reason,
+		Duration: time.Since(call.start),
+		Bytes:    atomic.LoadInt64(&call.bytes),
+		Chunks:   atomic.LoadInt64(&call.chunks),
+		At:       time.Now(),
+	}
+
+	rm.mtx.Lock()
+	defer rm.mtx.Unlock()
+
+	if len(rm.recent) < recentRunawaysCapacity {
+		rm.recent = append(rm.recent, record)
+	} else {
+		rm.recent[rm.recentIdx] = record
+		rm.recentIdx = (rm.recentIdx + 1) % recentRunawaysCapacity
+	}
+
+	rm.cooldownUntil[call.tenant] = time.Now().Add(cooldownFor)
+}
+
+// RecentRunawaysHandler is an http.HandlerFunc that returns the recent-runaways ring buffer as
+// JSON, meant to be registered on the runtime admin router, e.g. as
+// GET /loki/admin/downloads/runaways.
+func (rm *RunawayManager) RecentRunawaysHandler(w http.ResponseWriter, _ *http.Request) {
+	rm.mtx.Lock()
+	records := make([]RunawayRecord, len(rm.recent))
+	copy(records, rm.recent)
+	rm.mtx.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(records)
+}
@@ -0,0 +1,73 @@
+package downloads
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/validation"
+)
+
+type fakeRunawayLimits struct {
+	byUser map[string]*validation.Limits
+	defalt validation.Limits
+}
+
+func (f fakeRunawayLimits) AllByUserID() map[string]*validation.Limits { return f.byUser }
+func (f fakeRunawayLimits) DefaultLimits() *validation.Limits          { return &f.defalt }
+
+func TestRunawayManager_AddBytesTripsOnExceedingMax(t *testing.T) {
+	rm := NewRunawayManager(fakeRunawayLimits{
+		byUser: map[string]*validation.Limits{},
+		defalt: validation.Limits{MaxForEachBytes: 10},
+	})
+
+	ctx, call, err := rm.Register(context.Background(), "tenant-a", "table0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rm.Done(call)
+
+	call.AddBytes(rm, 5)
+	if ctx.Err() != nil {
+		t.Fatal("did not expect the call to be tripped yet")
+	}
+
+	call.AddBytes(rm, 10)
+	if ctx.Err() == nil {
+		t.Fatal("expected exceeding MaxForEachBytes to cancel the call's context")
+	}
+}
+
+func TestRunawayManager_TripPutsTenantInCooldownThenResets(t *testing.T) {
+	rm := NewRunawayManager(fakeRunawayLimits{
+		byUser: map[string]*validation.Limits{},
+		defalt: validation.Limits{MaxForEachChunks: 1},
+	})
+
+	ctx, call, err := rm.Register(context.Background(), "tenant-a", "table0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	call.AddChunk(rm)
+	call.AddChunk(rm)
+	if ctx.Err() == nil {
+		t.Fatal("expected exceeding MaxForEachChunks to cancel the call")
+	}
+	rm.Done(call)
+
+	if _, _, err := rm.Register(context.Background(), "tenant-a", "table0"); err == nil {
+		t.Fatal("expected a second call for the same tenant to be rejected during cooldown")
+	}
+
+	// force the cooldown to have elapsed and confirm the tenant can proceed again.
+	rm.mtx.Lock()
+	rm.cooldownUntil["tenant-a"] = time.Now().Add(-time.Second)
+	rm.mtx.Unlock()
+
+	_, call2, err := rm.Register(context.Background(), "tenant-a", "table0")
+	if err != nil {
+		t.Fatalf("expected the call to be admitted once cooldown has elapsed, got: %v", err)
+	}
+	rm.Done(call2)
+}
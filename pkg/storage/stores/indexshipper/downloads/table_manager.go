@@ -2,8 +2,12 @@ package downloads
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strconv"
@@ -23,8 +27,32 @@ import (
 const (
 	cacheCleanupInterval = time.Hour
 	durationDay          = 24 * time.Hour
+
+	// startupConcurrencyMultiplier scales DownloadConcurrency up for the initial catch-up
+	// download in NewTableManager; the pool is resized back down to DownloadConcurrency once
+	// that initial sync completes and the node settles into steady state.
+	startupConcurrencyMultiplier = 4
+
+	// assumedAverageIndexFileBytes is a rough per-file size estimate used to size rate-limiter
+	// token consumption for a table's download job, since the actual byte count of a file isn't
+	// known until it's fetched.
+	assumedAverageIndexFileBytes = 4 << 20
+
+	// estimatedBytesPerChunk is the per-chunk size estimate ForEach's callback falls back to
+	// feeding runawayCall.AddBytes when the index.Index visited doesn't implement sizedIndex, so
+	// MaxForEachBytes still has something to enforce against.
+	estimatedBytesPerChunk = 4 << 10
 )
 
+// sizedIndex is an optional extension of index.Index. An Index implementation that reports how
+// many bytes it read to serve the callback can implement it so ForEach's AddBytes tracking
+// reflects real I/O volume instead of a fixed per-chunk guess. Indexes that don't implement it
+// fall back to estimatedBytesPerChunk.
+type sizedIndex interface {
+	// Size returns the number of bytes read from the cache/backing store to serve this Index.
+	Size() int64
+}
+
 type Limits interface {
 	AllByUserID() map[string]*validation.Limits
 	DefaultLimits() *validation.Limits
@@ -35,6 +63,16 @@ type Limits interface {
 // It is only relevant by an IndexGateway in the ring mode and if it returns false for a given tenant, that tenant will be ignored by this IndexGateway during query readiness.
 type IndexGatewayOwnsTenant func(tenant string) bool
 
+// IndexGatewayShardTable is invoked by an IndexGateway instance in ring mode, for tenants it
+// already owns, to decide whether this particular instance should pre-download tableName for
+// tenant as part of query readiness. It is used to consistently hash tenant×table pairs across
+// a tenant's replica set (sized by the ring's replication factor), so that replicas for the same
+// tenant don't all redundantly pre-download the same set of tables. A replica that doesn't own a
+// given table still serves queries for it via the lazy getOrCreateTable/ForEach path.
+//
+// NewRingShardTable builds the consistent-hashing implementation of this type.
+type IndexGatewayShardTable func(tenant, tableName string) bool
+
 type TableManager interface {
 	Stop()
 	ForEach(ctx context.Context, tableName, userID string, callback index.ForEachIndexCallback) error
@@ -43,9 +81,39 @@ type TableManager interface {
 type Config struct {
 	CacheDir          string
 	SyncInterval      time.Duration
-	CacheTTL          time.Duration
 	QueryReadyNumDays int
 	Limits            Limits
+
+	// MinLease and MaxLease bound the per-user cache lease duration cleanupCache enforces in
+	// place of the old fixed CacheTTL sweep: a lease is renewed on every ForEach/
+	// EnsureQueryReadiness call and grows towards MaxLease for frequently-accessed users,
+	// decaying towards MinLease for ones that have gone cold.
+	//
+	// MaxLease doubles as the TTL cleanupCache falls back to for a table that never has any
+	// per-user lease tracked against it (e.g. one made up only of a legacy shared/common index
+	// file, rather than per-tenant ones), measured from when it was last loaded, synced, or
+	// downloaded.
+	MinLease, MaxLease time.Duration
+	// LeaseDecayFactor controls how fast a lease grows/shrinks between MinLease and MaxLease
+	// on each renewal; it must be in (0, 1), e.g. 0.5 doubles the lease on a hot renewal and
+	// halves it on a cold one.
+	LeaseDecayFactor float64
+
+	// DownloadConcurrency is the number of workers per node used to pre-download tables for
+	// query readiness. Defaults to 1 (i.e. the previous serial behaviour) when unset.
+	DownloadConcurrency int
+	// DownloadRateLimitMBps caps the aggregate rate, in megabytes per second, at which
+	// ensureQueryReadiness reads files from object storage across all workers. 0 disables
+	// rate limiting.
+	DownloadRateLimitMBps int
+
+	// VerifyChecksum enables SHA-256 checksum verification of the files cached for a table when
+	// the table is created, after each query readiness pre-download of it, and for every file
+	// found in CacheDir on startup. Files that fail verification are moved into
+	// <CacheDir>/.quarantine. Files that a ForEach call downloads on demand are not verified when
+	// they are fetched. It is a no-op if indexStorageClient doesn't implement
+	// ChecksumIndexStorageClient.
+	VerifyChecksum bool
 }
 
 type tableManager struct {
@@ -57,27 +125,37 @@ type tableManager struct {
 	tablesMtx sync.RWMutex
 	metrics   *metrics
 
+	downloadPool *downloadPool
+	runaway      *RunawayManager
+	leases       *leaseTracker
+
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 
 	ownsTenant IndexGatewayOwnsTenant
+	shardTable IndexGatewayShardTable
 }
 
 func NewTableManager(cfg Config, openIndexFileFunc index.OpenIndexFileFunc, indexStorageClient storage.Client,
-	ownsTenantFn IndexGatewayOwnsTenant, reg prometheus.Registerer) (TableManager, error) {
+	ownsTenantFn IndexGatewayOwnsTenant, shardTableFn IndexGatewayShardTable, reg prometheus.Registerer) (TableManager, error) {
 	if err := util.EnsureDirectory(cfg.CacheDir); err != nil {
 		return nil, err
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
+	m := newMetrics(reg)
 	tm := &tableManager{
 		cfg:                cfg,
 		openIndexFileFunc:  openIndexFileFunc,
 		indexStorageClient: indexStorageClient,
 		ownsTenant:         ownsTenantFn,
+		shardTable:         shardTableFn,
 		tables:             make(map[string]Table),
-		metrics:            newMetrics(reg),
+		metrics:            m,
+		downloadPool:       newDownloadPool(cfg.DownloadConcurrency*startupConcurrencyMultiplier, cfg.DownloadRateLimitMBps, m),
+		runaway:            NewRunawayManager(cfg.Limits),
+		leases:             newLeaseTracker(cfg.MinLease, cfg.MaxLease, cfg.LeaseDecayFactor, m),
 		ctx:                ctx,
 		cancel:             cancel,
 	}
@@ -90,7 +168,8 @@ func NewTableManager(cfg Config, openIndexFileFunc index.OpenIndexFileFunc, inde
 		return nil, err
 	}
 
-	// download the missing tables.
+	// download the missing tables, using a larger worker pool to get through the initial
+	// catch-up quickly.
 	err = tm.ensureQueryReadiness(ctx)
 	if err != nil {
 		// call Stop to close open file references.
@@ -98,6 +177,9 @@ func NewTableManager(cfg Config, openIndexFileFunc index.OpenIndexFileFunc, inde
 		return nil, err
 	}
 
+	// initial catch-up is done; scale the pool back down to its steady-state size.
+	tm.downloadPool.Resize(cfg.DownloadConcurrency)
+
 	go tm.loop()
 	return tm, nil
 }
@@ -139,6 +221,7 @@ func (tm *tableManager) loop() {
 func (tm *tableManager) Stop() {
 	tm.cancel()
 	tm.wg.Wait()
+	tm.downloadPool.Stop()
 
 	tm.tablesMtx.Lock()
 	defer tm.tablesMtx.Unlock()
@@ -148,12 +231,72 @@ func (tm *tableManager) Stop() {
 	}
 }
 
+// ResizeDownloadPool changes the number of workers used to pre-download tables for query
+// readiness. It is intended to be wired into a runtime admin endpoint so operators can scale
+// workers up during initial startup/catch-up and back down once steady state is reached,
+// without a restart.
+func (tm *tableManager) ResizeDownloadPool(n int) {
+	tm.downloadPool.Resize(n)
+}
+
+// ResizeDownloadPoolHandler is an http.HandlerFunc wrapper around ResizeDownloadPool, meant to
+// be registered on the runtime admin router, e.g. as POST /loki/admin/downloads/pool/resize.
+func (tm *tableManager) ResizeDownloadPoolHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Workers int `json:"workers"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Workers <= 0 {
+		http.Error(w, "workers must be a positive integer", http.StatusBadRequest)
+		return
+	}
+
+	tm.ResizeDownloadPool(req.Workers)
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (tm *tableManager) ForEach(ctx context.Context, tableName, userID string, callback index.ForEachIndexCallback) error {
+	if tm.shardTable != nil && !tm.shardTable(userID, tableName) {
+		// this replica doesn't own tableName for userID, but the query landed here anyway
+		// (e.g. a ring topology change); fall back to lazy on-demand download via
+		// getOrCreateTable rather than failing the query.
+		tm.metrics.crossShardLazyFetchesTotal.Inc()
+	}
+
+	ctx, runawayCall, err := tm.runaway.Register(ctx, userID, tableName)
+	if err != nil {
+		return err
+	}
+	defer tm.runaway.Done(runawayCall)
+
+	tm.leases.Renew(tableName, userID)
+
 	table, err := tm.getOrCreateTable(tableName)
 	if err != nil {
 		return err
 	}
-	return table.ForEach(ctx, userID, callback)
+
+	return table.ForEach(ctx, userID, func(isMultiTenantIndex bool, idx index.Index) error {
+		runawayCall.AddChunk(tm.runaway)
+		runawayCall.AddBytes(tm.runaway, indexBytesRead(idx))
+		return callback(isMultiTenantIndex, idx)
+	})
+}
+
+// indexBytesRead returns how many bytes idx reports reading, if it implements sizedIndex, or
+// estimatedBytesPerChunk otherwise.
+func indexBytesRead(idx index.Index) int64 {
+	sized, ok := idx.(sizedIndex)
+	if !ok {
+		return estimatedBytesPerChunk
+	}
+
+	return sized.Size()
 }
 
 func (tm *tableManager) getOrCreateTable(tableName string) (Table, error) {
@@ -180,12 +323,33 @@ func (tm *tableManager) getOrCreateTable(tableName string) (Table, error) {
 
 			table = NewTable(tableName, filepath.Join(tm.cfg.CacheDir, tableName), tm.indexStorageClient, tm.openIndexFileFunc, tm.metrics)
 			tm.tables[tableName] = table
+
+			if err := tm.verifyDownloadedTableFiles(tableName, tablePath); err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	return table, nil
 }
 
+// verifyDownloadedTableFiles checksums every file now cached under tablePath, quarantining any
+// that fail verification, the same way verifyLocalTableFiles does for files already on disk at
+// startup. It is a no-op unless cfg.VerifyChecksum is set and indexStorageClient supports
+// ChecksumIndexStorageClient.
+func (tm *tableManager) verifyDownloadedTableFiles(tableName, tablePath string) error {
+	if !tm.cfg.VerifyChecksum {
+		return nil
+	}
+
+	checksumClient, ok := tm.indexStorageClient.(ChecksumIndexStorageClient)
+	if !ok {
+		return nil
+	}
+
+	return tm.verifyLocalTableFiles(tableName, tablePath, checksumClient)
+}
+
 func (tm *tableManager) syncTables(ctx context.Context) error {
 	tm.tablesMtx.RLock()
 	defer tm.tablesMtx.RUnlock()
@@ -205,11 +369,13 @@ func (tm *tableManager) syncTables(ctx context.Context) error {
 
 	level.Info(util_log.Logger).Log("msg", "syncing tables")
 
-	for _, table := range tm.tables {
+	now := time.Now()
+	for name, table := range tm.tables {
 		err := table.Sync(ctx)
 		if err != nil {
 			return err
 		}
+		tm.leases.Touch(name, now)
 	}
 
 	return nil
@@ -221,21 +387,72 @@ func (tm *tableManager) cleanupCache() error {
 
 	level.Info(util_log.Logger).Log("msg", "cleaning tables cache")
 
+	now := time.Now()
 	for name, table := range tm.tables {
-		level.Info(util_log.Logger).Log("msg", fmt.Sprintf("cleaning up expired table %s", name))
-		isEmpty, err := table.DropUnusedIndex(tm.cfg.CacheTTL, time.Now())
-		if err != nil {
-			return err
+		expiredUsers := tm.leases.ExpiredUsers(name, now)
+		if len(expiredUsers) > 0 {
+			level.Info(util_log.Logger).Log("msg", fmt.Sprintf("dropping lease-expired index for %d user(s) in table %s", len(expiredUsers), name))
+			isEmpty, err := tm.dropExpiredUserIndex(name, expiredUsers)
+			if err != nil {
+				return err
+			}
+
+			if isEmpty && tm.leases.IsEmpty(name) {
+				if err := table.Close(); err != nil {
+					return err
+				}
+				delete(tm.tables, name)
+			}
+
+			continue
 		}
 
-		if isEmpty {
-			delete(tm.tables, name)
+		// name has no per-user lease tracked against it at all (e.g. a legacy table made up only
+		// of a shared/common index file), so ExpiredUsers/IsEmpty have nothing to report. Fall
+		// back to a straight TTL sweep keyed off when the table was last loaded/synced/
+		// downloaded, the same way the old fixed-CacheTTL behaviour cleaned these up.
+		if tm.leases.HasUsers(name) {
+			continue
+		}
+
+		if !tm.leases.TouchedTableExpired(name, tm.cfg.MaxLease, now) {
+			continue
+		}
+
+		level.Info(util_log.Logger).Log("msg", fmt.Sprintf("dropping stale shared index for table %s", name))
+		if err := table.Close(); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(filepath.Join(tm.cfg.CacheDir, name)); err != nil {
+			return err
 		}
+		delete(tm.tables, name)
 	}
 
 	return nil
 }
 
+// dropExpiredUserIndex removes the on-disk per-user index directory for each userID in
+// tableName, without touching any common (non-per-tenant) index files cached alongside them. It
+// reports isEmpty=true once tableName's cache directory has nothing left in it at all, so the
+// caller knows it's safe to also drop the in-memory Table and its open file handles.
+func (tm *tableManager) dropExpiredUserIndex(tableName string, userIDs []string) (isEmpty bool, err error) {
+	tablePath := filepath.Join(tm.cfg.CacheDir, tableName)
+
+	for _, userID := range userIDs {
+		if err := os.RemoveAll(filepath.Join(tablePath, userID)); err != nil {
+			return false, err
+		}
+	}
+
+	entries, err := ioutil.ReadDir(tablePath)
+	if err != nil {
+		return false, err
+	}
+
+	return len(entries) == 0, nil
+}
+
 // ensureQueryReadiness compares tables required for being query ready with the tables we already have and downloads the missing ones.
 func (tm *tableManager) ensureQueryReadiness(ctx context.Context) error {
 	start := time.Now()
@@ -277,6 +494,7 @@ func (tm *tableManager) ensureQueryReadiness(ctx context.Context) error {
 		return err
 	}
 
+	var jobs []downloadJob
 	for _, tableName := range tables {
 		match := re.Find([]byte(tableName))
 		if match == nil {
@@ -294,37 +512,66 @@ func (tm *tableManager) ensureQueryReadiness(ctx context.Context) error {
 		}
 
 		// list the users that have dedicated index files for this table
-		_, usersWithIndex, err := tm.indexStorageClient.ListFiles(ctx, tableName, false)
+		indexFiles, usersWithIndex, err := tm.indexStorageClient.ListFiles(ctx, tableName, false)
 		if err != nil {
 			return err
 		}
 
+		// estimatedBytes scales how many tokens Dispatch takes from the download pool's rate
+		// limiter before admitting this job. We don't know ahead of time exactly how many bytes
+		// a file is until it's fetched, so this approximates total transfer size from the
+		// table's file count rather than leaving the rate limiter unaware of job size entirely.
+		estimatedBytes := int64(len(indexFiles)) * assumedAverageIndexFileBytes
+
 		// find the users whos index we need to keep ready for querying from this table
-		usersToBeQueryReadyFor := tm.findUsersInTableForQueryReadiness(tableNumber, usersWithIndex, queryReadinessNumByUserID)
+		usersToBeQueryReadyFor := tm.findUsersInTableForQueryReadiness(tableName, tableNumber, usersWithIndex, queryReadinessNumByUserID)
 
 		// continue if both user index and common index is not required to be downloaded for query readiness
 		if len(usersToBeQueryReadyFor) == 0 && activeTableNumber-tableNumber > int64(tm.cfg.QueryReadyNumDays) {
 			continue
 		}
 
-		table, err := tm.getOrCreateTable(tableName)
-		if err != nil {
-			return err
-		}
-
-		perTableStart := time.Now()
-		if err := table.EnsureQueryReadiness(ctx, usersToBeQueryReadyFor); err != nil {
-			return err
-		}
-		level.Info(util_log.Logger).Log("msg", "index pre-download for query readiness completed", "users_len", len(usersToBeQueryReadyFor), "duration", time.Since(perTableStart), "table", tableName)
+		tableName, usersToBeQueryReadyFor := tableName, usersToBeQueryReadyFor
+		jobs = append(jobs, downloadJob{
+			tableName:      tableName,
+			estimatedBytes: estimatedBytes,
+			run: func() error {
+				table, err := tm.getOrCreateTable(tableName)
+				if err != nil {
+					return err
+				}
+
+				perTableStart := time.Now()
+				if err := table.EnsureQueryReadiness(ctx, usersToBeQueryReadyFor); err != nil {
+					return err
+				}
+
+				if err := tm.verifyDownloadedTableFiles(tableName, filepath.Join(tm.cfg.CacheDir, tableName)); err != nil {
+					return err
+				}
+
+				tm.leases.Touch(tableName, time.Now())
+				for _, userID := range usersToBeQueryReadyFor {
+					tm.leases.Renew(tableName, userID)
+				}
+				level.Info(util_log.Logger).Log("msg", "index pre-download for query readiness completed", "users_len", len(usersToBeQueryReadyFor), "duration", time.Since(perTableStart), "table", tableName)
+				return nil
+			},
+		})
 	}
 
-	return nil
+	// dispatch per-table (and, within findUsersInTableForQueryReadiness, implicitly per-user)
+	// jobs across the resizable worker pool instead of downloading tables one at a time; the
+	// pool returns the first error and abandons jobs still queued behind it, matching the previous
+	// serial "return on first error" behaviour.
+	return tm.downloadPool.Dispatch(ctx, jobs)
 }
 
 // findUsersInTableForQueryReadiness returns the users that needs their index to be query ready based on the tableNumber and
-// query readiness number provided per user
-func (tm *tableManager) findUsersInTableForQueryReadiness(tableNumber int64, usersWithIndexInTable []string,
+// query readiness number provided per user. tableName is used to consult shardTable, which
+// further narrows ownership down to this replica's shard of tableName within the tenant's
+// replica set.
+func (tm *tableManager) findUsersInTableForQueryReadiness(tableName string, tableNumber int64, usersWithIndexInTable []string,
 	queryReadinessNumByUserID map[string]int) []string {
 	activeTableNumber := getActiveTableNumber()
 	usersToBeQueryReadyFor := []string{}
@@ -344,6 +591,13 @@ func (tm *tableManager) findUsersInTableForQueryReadiness(tableNumber int64, use
 			continue
 		}
 
+		if tm.shardTable != nil {
+			if !tm.shardTable(userID, tableName) {
+				continue
+			}
+			tm.metrics.shardOwnershipTotal.Inc()
+		}
+
 		if activeTableNumber-tableNumber <= int64(queryReadyNumDays) {
 			usersToBeQueryReadyFor = append(usersToBeQueryReadyFor, userID)
 		}
@@ -359,25 +613,138 @@ func (tm *tableManager) loadLocalTables() error {
 		return err
 	}
 
+	checksumClient, checksumsSupported := tm.indexStorageClient.(ChecksumIndexStorageClient)
+
 	for _, fileInfo := range filesInfo {
-		if !fileInfo.IsDir() {
+		if !fileInfo.IsDir() || fileInfo.Name() == quarantineDirName {
 			continue
 		}
 
 		level.Info(util_log.Logger).Log("msg", fmt.Sprintf("loading local table %s", fileInfo.Name()))
 
-		table, err := LoadTable(fileInfo.Name(), filepath.Join(tm.cfg.CacheDir, fileInfo.Name()),
-			tm.indexStorageClient, tm.openIndexFileFunc, tm.metrics)
+		tableName := fileInfo.Name()
+		tablePath := filepath.Join(tm.cfg.CacheDir, tableName)
+
+		if tm.cfg.VerifyChecksum && checksumsSupported {
+			if err := tm.verifyLocalTableFiles(tableName, tablePath, checksumClient); err != nil {
+				return err
+			}
+		}
+
+		table, err := LoadTable(tableName, tablePath, tm.indexStorageClient, tm.openIndexFileFunc, tm.metrics)
 		if err != nil {
 			return err
 		}
 
-		tm.tables[fileInfo.Name()] = table
+		tm.tables[tableName] = table
+
+		// seed a lease for every user whose index is already cached, so a table loaded here but
+		// never queried again after this restart still has a lease that eventually expires and
+		// gets swept by cleanupCache, instead of leaking on disk forever.
+		if err := tm.seedLeasesForLocalTable(tableName, tablePath); err != nil {
+			return err
+		}
+
+		// Touch unconditionally too, covering tables that turn out to have no per-user
+		// subdirectory at all (e.g. legacy shared/common-index-only tables), which cleanupCache
+		// otherwise has no lease information to sweep against.
+		tm.leases.Touch(tableName, time.Now())
+	}
+
+	return nil
+}
+
+// seedLeasesForLocalTable renews a lease for every userID with a per-user index directory cached
+// under tablePath, as found by loadLocalTables at startup. Per-user index files live in
+// tablePath/<userID>/, one level below the table directory itself.
+func (tm *tableManager) seedLeasesForLocalTable(tableName, tablePath string) error {
+	entries, err := ioutil.ReadDir(tablePath)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		tm.leases.Renew(tableName, entry.Name())
 	}
 
 	return nil
 }
 
+// verifyLocalTableFiles checks every file cached under tablePath against the checksum reported
+// by client, quarantining any that fail verification so a node that restarts after a disk
+// corruption event does not silently serve bad data to a query. It is called before LoadTable so
+// quarantined files are simply absent from the table once loaded, triggering a re-download the
+// next time ensureQueryReadiness/getOrCreateTable runs.
+//
+// tablePath contains both legacy shared/common index files directly under it and, one level
+// down, a per-user subdirectory (tablePath/<userID>/...) holding that user's dedicated index
+// files (see dropExpiredUserIndex/seedLeasesForLocalTable). Both layouts are walked so
+// per-tenant files - the majority of files in a real multi-tenant deployment - are actually
+// checksummed instead of silently skipped.
+func (tm *tableManager) verifyLocalTableFiles(tableName, tablePath string, client ChecksumIndexStorageClient) error {
+	entries, err := ioutil.ReadDir(tablePath)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			if err := tm.verifyUserTableFiles(tableName, entry.Name(), filepath.Join(tablePath, entry.Name()), client); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := tm.verifyAndQuarantine(tableName, entry.Name(), filepath.Join(tablePath, entry.Name()), client); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifyUserTableFiles is the per-user counterpart of verifyLocalTableFiles, walking the index
+// files dedicated to a single userID under userPath.
+func (tm *tableManager) verifyUserTableFiles(tableName, userID, userPath string, client ChecksumIndexStorageClient) error {
+	entries, err := ioutil.ReadDir(userPath)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		checksumKey := path.Join(userID, entry.Name())
+		if err := tm.verifyAndQuarantine(tableName, checksumKey, filepath.Join(userPath, entry.Name()), client); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifyAndQuarantine checksums the single file at filePath, identified to client and to the
+// quarantine directory layout by checksumKey (just the file's base name for a legacy shared
+// file, or "<userID>/<fileName>" for a per-user one).
+func (tm *tableManager) verifyAndQuarantine(tableName, checksumKey, filePath string, client ChecksumIndexStorageClient) error {
+	ok, err := verifyChecksum(tm.ctx, client, tableName, checksumKey, filePath)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		return nil
+	}
+
+	tm.metrics.tablesCorruptionTotal.WithLabelValues("checksum_mismatch").Inc()
+	return quarantineFile(tm.cfg.CacheDir, tableName, checksumKey, filePath)
+}
+
 func getActiveTableNumber() int64 {
 	periodSecs := int64(durationDay / time.Second)
 